@@ -16,6 +16,16 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dcos/dcos-go/exec"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -35,18 +45,211 @@ func init() {
 	RootCmd.AddCommand(clusterVersionCmd)
 }
 
+const (
+	// maxDistinctVersions is the most versions a healthy cluster may report at once,
+	// which happens transiently during a rolling upgrade.
+	maxDistinctVersions = 2
+
+	// perNodeTimeout bounds how long we wait on any single node before counting it as
+	// unreachable.
+	perNodeTimeout = 10 * time.Second
+
+	// unreachableExitOffset is added to the unreachable node count when the exit code
+	// also needs to signal that the version-count invariant was violated.
+	unreachableExitOffset = 100
+)
+
+var (
+	// mesosMasterStateURL is the local admin router endpoint used to enumerate the
+	// cluster's masters and agents. It is always reachable from any node, since the
+	// admin router proxies to whichever master is currently leading. Overridden in
+	// tests to point at an httptest server.
+	mesosMasterStateURL = "http://localhost:1050/mesos/master/state"
+
+	// versionEndpointURL builds the dcos-diagnostics version endpoint for node.
+	// Overridden in tests to point at an httptest server regardless of node.
+	versionEndpointURL = func(node string) string {
+		return fmt.Sprintf("http://%s:61001/system/health/v1/dcos-version.json", node)
+	}
+
+	// sshRunnerFor builds the Runner used to fall back to SSH when a node's HTTP
+	// version endpoint is unreachable. Overridden in tests with a fake Runner.
+	sshRunnerFor = func(node string) exec.Runner {
+		return exec.NewSSHRunner(node)
+	}
+)
+
 // ClusterVersionCheck validates the cluster has no more than 2 versions
 type ClusterVersionCheck struct {
 	Name string
+
+	// MaxUnreachableNodes is the number of nodes allowed to be unreachable before the
+	// check fails outright, regardless of the version invariant.
+	MaxUnreachableNodes int
+}
+
+// NewClusterVersionCheck returns a ClusterVersionCheck with reasonable defaults.
+func NewClusterVersionCheck(name string) *ClusterVersionCheck {
+	return &ClusterVersionCheck{Name: name, MaxUnreachableNodes: 0}
+}
+
+// nodeVersion is the outcome of querying a single node for its DC/OS version.
+type nodeVersion struct {
+	node    string
+	version string
+	err     error
+}
+
+// mesosState is the subset of GET /mesos/master/state this check needs.
+type mesosState struct {
+	LeaderInfo struct {
+		Hostname string `json:"hostname"`
+	} `json:"leader_info"`
+	Slaves []struct {
+		Hostname string `json:"hostname"`
+	} `json:"slaves"`
+}
+
+// dcosVersionInfo is the shape of /system/health/v1/dcos-version.json.
+type dcosVersionInfo struct {
+	Version string `json:"version"`
 }
 
 // Run invokes a cluster version check and return error output, exit code and error.
 func (c *ClusterVersionCheck) Run(ctx context.Context, cfg *CLIConfigFlags) (string, int, error) {
-	// Get a list of all masters
-	// Get a list of all agents
-	// Get versions for each and throw in array?
-	// Error if more than 2
-	return "", 0, nil
+	nodes, err := clusterNodes(ctx)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "unable to enumerate cluster nodes")
+	}
+
+	results := make([]nodeVersion, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node string) {
+			defer wg.Done()
+			results[i] = nodeVersionFor(ctx, node)
+		}(i, node)
+	}
+	wg.Wait()
+
+	var (
+		lines       []string
+		unreachable int
+	)
+	versions := map[string]bool{}
+	for _, r := range results {
+		if r.err != nil {
+			unreachable++
+			lines = append(lines, fmt.Sprintf("%s: error: %s", r.node, r.err))
+			continue
+		}
+		versions[r.version] = true
+		lines = append(lines, fmt.Sprintf("%s: %s", r.node, r.version))
+	}
+	sort.Strings(lines)
+	summary := strings.Join(lines, "\n")
+
+	if len(versions) > maxDistinctVersions {
+		return summary, unreachable + unreachableExitOffset, errors.Errorf(
+			"cluster is running %d distinct versions, expected at most %d", len(versions), maxDistinctVersions)
+	}
+
+	if unreachable > c.MaxUnreachableNodes {
+		return summary, unreachable, errors.Errorf(
+			"%d node(s) unreachable, expected at most %d", unreachable, c.MaxUnreachableNodes)
+	}
+
+	return summary, 0, nil
+}
+
+// clusterNodes returns the leading master's hostname and every agent hostname known to
+// mesos. Masters other than the leader are not included.
+func clusterNodes(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequest(http.MethodGet, mesosMasterStateURL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to build mesos state request")
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to reach local admin router")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %d from %s", resp.StatusCode, mesosMasterStateURL)
+	}
+
+	var state mesosState
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return nil, errors.Wrap(err, "unable to decode mesos state")
+	}
+
+	nodes := []string{state.LeaderInfo.Hostname}
+	for _, slave := range state.Slaves {
+		nodes = append(nodes, slave.Hostname)
+	}
+	return nodes, nil
+}
+
+// nodeVersionFor fetches a single node's DC/OS version, first over HTTP against its
+// dcos-diagnostics endpoint and, if that's unreachable, by SSHing in and reading the
+// version file directly.
+func nodeVersionFor(ctx context.Context, node string) nodeVersion {
+	ctx, cancel := context.WithTimeout(ctx, perNodeTimeout)
+	defer cancel()
+
+	if version, err := versionOverHTTP(ctx, node); err == nil {
+		return nodeVersion{node: node, version: version}
+	}
+
+	version, err := versionOverSSH(ctx, node)
+	if err != nil {
+		return nodeVersion{node: node, err: err}
+	}
+	return nodeVersion{node: node, version: version}
+}
+
+func versionOverHTTP(ctx context.Context, node string) (string, error) {
+	url := versionEndpointURL(node)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to build version request")
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to reach %s", node)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	var info dcosVersionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", errors.Wrapf(err, "unable to decode version response from %s", node)
+	}
+	return info.Version, nil
+}
+
+func versionOverSSH(ctx context.Context, node string) (string, error) {
+	runner := sshRunnerFor(node)
+	stdout, _, err := runner.Output(ctx, exec.Cmd{Path: "cat", Args: []string{"/opt/mesosphere/etc/dcos-version.json"}})
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to read version file on %s over SSH", node)
+	}
+
+	var info dcosVersionInfo
+	if err := json.Unmarshal(stdout, &info); err != nil {
+		return "", errors.Wrapf(err, "unable to decode version file from %s", node)
+	}
+	return info.Version, nil
 }
 
 // ID returns a unique check identifier.
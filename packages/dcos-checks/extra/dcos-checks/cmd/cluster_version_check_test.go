@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dcos/dcos-go/exec"
+)
+
+// stubMesosState starts an httptest server serving the given nodes as a mesos
+// /master/state response and points mesosMasterStateURL at it. The caller must run the
+// returned func to restore the original and release the server.
+func stubMesosState(leader string, agents ...string) func() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := mesosState{}
+		state.LeaderInfo.Hostname = leader
+		for _, a := range agents {
+			state.Slaves = append(state.Slaves, struct {
+				Hostname string `json:"hostname"`
+			}{Hostname: a})
+		}
+		json.NewEncoder(w).Encode(state)
+	}))
+
+	orig := mesosMasterStateURL
+	mesosMasterStateURL = server.URL
+	return func() {
+		server.Close()
+		mesosMasterStateURL = orig
+	}
+}
+
+// stubVersions starts an httptest server reporting versions[node] for each node's
+// dcos-diagnostics version endpoint. A node absent from versions gets a 404, simulating
+// an unreachable node that should fall back to SSH. The caller must run the returned
+// func to restore the original and release the server.
+func stubVersions(versions map[string]string) func() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		node := r.URL.Query().Get("node")
+		version, ok := versions[node]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(dcosVersionInfo{Version: version})
+	}))
+
+	orig := versionEndpointURL
+	versionEndpointURL = func(node string) string {
+		return server.URL + "/system/health/v1/dcos-version.json?node=" + node
+	}
+	return func() {
+		server.Close()
+		versionEndpointURL = orig
+	}
+}
+
+func TestClusterVersionCheckPassesWhenAllNodesMatch(t *testing.T) {
+	defer stubMesosState("master1", "agent1", "agent2")()
+	defer stubVersions(map[string]string{"master1": "2.0.0", "agent1": "2.0.0", "agent2": "2.0.0"})()
+
+	check := NewClusterVersionCheck("test")
+	_, code, err := check.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+}
+
+func TestClusterVersionCheckFailsOnTooManyVersions(t *testing.T) {
+	defer stubMesosState("master1", "agent1", "agent2")()
+	defer stubVersions(map[string]string{"master1": "1.0.0", "agent1": "2.0.0", "agent2": "3.0.0"})()
+
+	check := NewClusterVersionCheck("test")
+	_, code, err := check.Run(context.Background(), nil)
+	if err == nil {
+		t.Fatal("expected an error when more than 2 distinct versions are reported")
+	}
+	if code < unreachableExitOffset {
+		t.Fatalf("expected exit code to carry the unreachable offset, got %d", code)
+	}
+}
+
+func TestClusterVersionCheckFallsBackToSSH(t *testing.T) {
+	defer stubMesosState("master1")()
+	defer stubVersions(map[string]string{})() // every node 404s over HTTP
+
+	orig := sshRunnerFor
+	sshRunnerFor = func(node string) exec.Runner { return &fakeSSHRunner{version: "2.1.0"} }
+	defer func() { sshRunnerFor = orig }()
+
+	check := NewClusterVersionCheck("test")
+	summary, code, err := check.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if want := "master1: 2.1.0"; summary != want {
+		t.Fatalf("summary = %q, want %q", summary, want)
+	}
+}
+
+// fakeSSHRunner implements exec.Runner and answers Output with a canned
+// dcos-version.json payload, standing in for a real SSH session.
+type fakeSSHRunner struct {
+	version string
+}
+
+func (f *fakeSSHRunner) Run(ctx context.Context, cmd exec.Cmd) (*exec.CommandExecutor, error) {
+	panic("not used by ClusterVersionCheck")
+}
+
+func (f *fakeSSHRunner) Output(ctx context.Context, cmd exec.Cmd) ([]byte, []byte, error) {
+	payload, err := json.Marshal(dcosVersionInfo{Version: f.version})
+	return payload, nil, err
+}
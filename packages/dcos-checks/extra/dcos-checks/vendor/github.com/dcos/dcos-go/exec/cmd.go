@@ -0,0 +1,156 @@
+package exec
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// Cmd describes a command to execute, the environment it should run in, and where its
+// streams should go. It is shared by the local, SSH and container Runners; a Runner is
+// free to ignore fields it cannot honor (e.g. Dir and Env over SSH are approximated with
+// a `cd`/`export` prefix on the remote command line, since an SSH session has no
+// equivalent of os/exec's Cmd.Dir/Cmd.Env).
+type Cmd struct {
+	// Path is the executable to run.
+	Path string
+	// Args are the arguments passed to Path. Unlike os/exec, Args does not include Path
+	// itself as Args[0].
+	Args []string
+
+	// Dir is the working directory of the command. If empty, the command runs in the
+	// caller's current directory.
+	Dir string
+	// Env holds the environment of the command, in "key=value" form. If nil, the
+	// command inherits the caller's environment, same as os/exec.
+	Env []string
+
+	// Stdin, if set, is connected to the command's standard input.
+	Stdin io.Reader
+	// Stdout and Stderr, if set, additionally receive a copy of the command's output.
+	// Outside Streamed mode, the CommandExecutor returned by RunCmd exposes the merged
+	// stream through Read regardless of whether these are set.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// StdoutPath and StderrPath, if set, tee the corresponding stream to a file on disk
+	// in addition to Stdout/Stderr and the CommandExecutor's merged reader. Useful for
+	// long-running diagnostics that want an on-disk log while a caller still consumes
+	// output live.
+	StdoutPath string
+	StderrPath string
+
+	timeout          time.Duration
+	killGracePeriod  time.Duration
+	mergeStderr      bool
+	mergeStderrSet   bool
+	streamed         bool
+	streamBufferSize int
+}
+
+// Option configures a Cmd before it is handed to RunCmd.
+type Option func(*Cmd)
+
+// Timeout bounds how long the command may run before it is killed, same as passing a
+// context with a deadline.
+func Timeout(d time.Duration) Option {
+	return func(c *Cmd) {
+		c.timeout = d
+	}
+}
+
+// KillGracePeriod sends SIGTERM on cancellation/timeout and escalates to SIGKILL only if
+// the process group hasn't exited within d. Without this option cancellation kills the
+// process group immediately.
+func KillGracePeriod(d time.Duration) Option {
+	return func(c *Cmd) {
+		c.killGracePeriod = d
+	}
+}
+
+// WithGracefulShutdown is an alias for KillGracePeriod: on cancellation or timeout, the
+// process group receives SIGTERM and is given gracePeriod to exit on its own before
+// being escalated to SIGKILL. A command that exits during gracePeriod reports
+// ErrGracefulShutdown on Done rather than ErrTimeout/ErrCanceled.
+func WithGracefulShutdown(gracePeriod time.Duration) Option {
+	return KillGracePeriod(gracePeriod)
+}
+
+// PropagateEnv copies the named variables from the caller's environment into Cmd.Env, in
+// addition to whatever Env already holds.
+func PropagateEnv(keys []string) Option {
+	return func(c *Cmd) {
+		for _, key := range keys {
+			if v, ok := os.LookupEnv(key); ok {
+				c.Env = append(c.Env, key+"="+v)
+			}
+		}
+	}
+}
+
+// MergeStderr controls whether stderr is written to Cmd.Stdout (merged) rather than
+// Cmd.Stderr (split). It has no effect on the CommandExecutor's own Read, which returns
+// the merged stream outside Streamed mode.
+func MergeStderr(merge bool) Option {
+	return func(c *Cmd) {
+		c.mergeStderr = merge
+		c.mergeStderrSet = true
+	}
+}
+
+// Streamed enables CommandExecutor.Stdout, Stderr and Frames, each stream backed by a
+// ring buffer capped at bufferSize bytes (defaultStreamBufferSize if bufferSize <= 0).
+// Once a stream's buffer is full, the oldest unread bytes are dropped and
+// CommandExecutor.Truncated reports true; a slow reader can fall behind without
+// blocking the command.
+//
+// Streamed mode supersedes CommandExecutor's merged Read(): callers are expected to
+// read Stdout()/Stderr()/Frames() instead, and Read() simply reports EOF once the
+// command exits.
+func Streamed(bufferSize int) Option {
+	return func(c *Cmd) {
+		c.streamed = true
+		c.streamBufferSize = bufferSize
+	}
+}
+
+// RunCmd spawns cmd on the local host after applying opts, returning a handle to the
+// running process in the same form as Run. It is the entry point for callers that need
+// stdin, a working directory, environment overrides, or on-disk log files - none of
+// which fit through the positional Run/Output wrappers.
+func RunCmd(ctx context.Context, cmd Cmd, opts ...Option) (*CommandExecutor, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for _, opt := range opts {
+		opt(&cmd)
+	}
+
+	if cmd.timeout <= 0 {
+		return defaultRunner.Run(ctx, cmd)
+	}
+
+	var cancel context.CancelFunc
+	ctx, cancel = context.WithTimeout(ctx, cmd.timeout)
+
+	commandExecutor, err := defaultRunner.Run(ctx, cmd)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// Release the timeout context's resources as soon as the command finishes rather
+	// than waiting out the rest of cmd.timeout. This reads commandExecutor.Done itself
+	// and republishes it on a fresh channel, so it doesn't steal the single value the
+	// caller is expected to read from Done.
+	done := commandExecutor.Done
+	commandExecutor.Done = make(chan error, 1)
+	go func() {
+		defer cancel()
+		commandExecutor.Done <- <-done
+	}()
+
+	return commandExecutor, nil
+}
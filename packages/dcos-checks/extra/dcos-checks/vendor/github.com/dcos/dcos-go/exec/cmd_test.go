@@ -0,0 +1,50 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunCmdTeesStdoutToFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exec-runcmd-test")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	stdoutPath := filepath.Join(dir, "nested", "stdout.log")
+	var buf bytes.Buffer
+
+	ce, err := RunCmd(context.Background(), Cmd{
+		Path:       "echo",
+		Args:       []string{"hello"},
+		Stdout:     &buf,
+		StdoutPath: stdoutPath,
+	})
+	if err != nil {
+		t.Fatalf("RunCmd returned error: %s", err)
+	}
+
+	if _, err := ioutil.ReadAll(ce); err != nil {
+		t.Fatalf("unable to read command output: %s", err)
+	}
+	if err := <-ce.Done; err != nil {
+		t.Fatalf("command failed: %s", err)
+	}
+
+	if got := buf.String(); got != "hello\n" {
+		t.Fatalf("Stdout writer got %q, want %q", got, "hello\n")
+	}
+
+	fileContents, err := ioutil.ReadFile(stdoutPath)
+	if err != nil {
+		t.Fatalf("unable to read tee file: %s", err)
+	}
+	if string(fileContents) != "hello\n" {
+		t.Fatalf("tee file got %q, want %q", fileContents, "hello\n")
+	}
+}
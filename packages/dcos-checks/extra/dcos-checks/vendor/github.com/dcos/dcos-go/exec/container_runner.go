@@ -0,0 +1,72 @@
+package exec
+
+import (
+	"context"
+)
+
+// ContainerRunner executes commands inside a running container via `docker exec`.
+// containerd's `ctr task exec` is not used, whichever runtime owns the target container
+// ID. It is used by diagnostics checks that need to inspect a process's view
+// from inside its container rather than from the host.
+type ContainerRunner struct {
+	containerID string
+	local       *LocalRunner
+}
+
+// NewContainerRunner returns a Runner that execs commands inside the container
+// identified by id.
+func NewContainerRunner(id string) *ContainerRunner {
+	return &ContainerRunner{containerID: id, local: NewLocalRunner()}
+}
+
+// Run execs cmd inside the container and streams its combined stdout/stderr through a
+// CommandExecutor, same as the local Runner.
+//
+// ctx's deadline/cancellation and the returned CommandExecutor's Signal only ever reach
+// the local `docker exec` client process, not the process it execs inside the
+// container: killing the client tears down the connection, but Docker leaves the
+// exec'd process running. Callers that need the remote process to actually stop on
+// cancellation must arrange for that themselves (e.g. have cmd self-terminate on its own
+// timeout, or kill it by PID via a separate `docker exec <id> kill` call).
+func (r *ContainerRunner) Run(ctx context.Context, cmd Cmd) (*CommandExecutor, error) {
+	return r.local.Run(ctx, r.wrap(cmd))
+}
+
+// Output execs cmd inside the container to completion and returns its buffered stdout
+// and stderr. See Run's doc comment for the same caveat about ctx cancellation not
+// reaching the remote process.
+func (r *ContainerRunner) Output(ctx context.Context, cmd Cmd) ([]byte, []byte, error) {
+	return r.local.Output(ctx, r.wrap(cmd))
+}
+
+// wrap rewrites cmd to run under the host's container exec tooling. It always uses
+// `docker exec`, with no fallback to another runtime; checks targeting a
+// containerd-only node can construct the equivalent `ctr` Cmd directly.
+//
+// Dir and Env describe the environment cmd.Path should see *inside* the container, not
+// the host `docker` process, so they're translated into `-w`/`-e` flags rather than left
+// on the wrapped Cmd, where the local Runner would apply them to `docker` itself. Stdin,
+// Stdout, Stderr, StdoutPath, StderrPath and the options set by Timeout, KillGracePeriod,
+// MergeStderr and Streamed all apply the same way to the host-side `docker exec` process
+// as they would to any other local command, so they carry over unchanged.
+func (r *ContainerRunner) wrap(cmd Cmd) Cmd {
+	args := []string{"exec"}
+	if cmd.Stdin != nil {
+		args = append(args, "-i")
+	}
+	if cmd.Dir != "" {
+		args = append(args, "-w", cmd.Dir)
+	}
+	for _, kv := range cmd.Env {
+		args = append(args, "-e", kv)
+	}
+	args = append(args, r.containerID, cmd.Path)
+	args = append(args, cmd.Args...)
+
+	wrapped := cmd
+	wrapped.Path = "docker"
+	wrapped.Args = args
+	wrapped.Dir = ""
+	wrapped.Env = nil
+	return wrapped
+}
@@ -0,0 +1,66 @@
+package exec
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestContainerRunnerWrap(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	var stdin bytes.Buffer
+
+	cases := []struct {
+		name string
+		cmd  Cmd
+		want Cmd
+	}{
+		{
+			name: "path and args are nested under docker exec <id>",
+			cmd:  Cmd{Path: "ps", Args: []string{"aux"}},
+			want: Cmd{Path: "docker", Args: []string{"exec", "c1", "ps", "aux"}},
+		},
+		{
+			name: "Stdin sets -i",
+			cmd:  Cmd{Path: "cat", Stdin: &stdin},
+			want: Cmd{Path: "docker", Args: []string{"exec", "-i", "c1", "cat"}, Stdin: &stdin},
+		},
+		{
+			name: "Dir becomes -w and is cleared on the wrapped Cmd",
+			cmd:  Cmd{Path: "pwd", Dir: "/srv/app"},
+			want: Cmd{Path: "docker", Args: []string{"exec", "-w", "/srv/app", "c1", "pwd"}},
+		},
+		{
+			name: "Env becomes repeated -e flags and is cleared on the wrapped Cmd",
+			cmd:  Cmd{Path: "env", Env: []string{"A=1", "B=2"}},
+			want: Cmd{Path: "docker", Args: []string{"exec", "-e", "A=1", "-e", "B=2", "c1", "env"}},
+		},
+		{
+			name: "Stdout/Stderr carry through unchanged",
+			cmd:  Cmd{Path: "echo", Args: []string{"hi"}, Stdout: &stdout, Stderr: &stderr},
+			want: Cmd{Path: "docker", Args: []string{"exec", "c1", "echo", "hi"}, Stdout: &stdout, Stderr: &stderr},
+		},
+		{
+			name: "KillGracePeriod option carries through unchanged",
+			cmd:  applyOpts(Cmd{Path: "echo"}, KillGracePeriod(5*time.Second)),
+			want: applyOpts(Cmd{Path: "docker", Args: []string{"exec", "c1", "echo"}}, KillGracePeriod(5*time.Second)),
+		},
+	}
+
+	r := NewContainerRunner("c1")
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := r.wrap(c.cmd); !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("wrap() = %+v, want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func applyOpts(cmd Cmd, opts ...Option) Cmd {
+	for _, opt := range opts {
+		opt(&cmd)
+	}
+	return cmd
+}
@@ -0,0 +1,22 @@
+package exec
+
+import "github.com/pkg/errors"
+
+// These are sent on CommandExecutor.Done instead of the raw context error so callers can
+// tell why a command stopped without string-matching on context.DeadlineExceeded /
+// context.Canceled.
+var (
+	// ErrTimeout means the context passed to Run/RunCmd reached its deadline and the
+	// command was killed without a chance to shut down gracefully (no grace period was
+	// configured, or the grace period elapsed without the process exiting).
+	ErrTimeout = errors.New("exec: command timed out")
+
+	// ErrCanceled means the context passed to Run/RunCmd was canceled by the caller and
+	// the command was killed without a chance to shut down gracefully.
+	ErrCanceled = errors.New("exec: command was canceled")
+
+	// ErrGracefulShutdown means the context was done (by timeout or cancellation) and
+	// the command exited on its own after receiving SIGTERM, within its configured
+	// KillGracePeriod/WithGracefulShutdown window, without needing SIGKILL.
+	ErrGracefulShutdown = errors.New("exec: command exited after a graceful shutdown request")
+)
@@ -1,10 +1,10 @@
 package exec
 
 import (
-	"bytes"
 	"context"
 	"io"
-	"os/exec"
+	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
@@ -29,63 +29,90 @@ var ErrInvalidTimeout = errors.New("Timeout cannot be negative or empty")
 // Done is a channel the user can read in order to retrieve execution status. Possible statuses:
 //  <nil> command executed successfully, returned 0 exit code
 //  <exit status N> where N is non 0 exit status.
-//  <context deadline exceeded> means timeout was reached and command was killed.
-//  <context canceled>  means that command was canceled by a user.
+//  ErrTimeout means the context's deadline was reached and the command was killed.
+//  ErrCanceled means the command was canceled by a user.
+//  ErrGracefulShutdown means the command exited on its own after SIGTERM, within its
+//   configured grace period, rather than needing SIGKILL.
 type CommandExecutor struct {
 	Done chan error
 
 	done chan error
 	pipe *io.PipeReader
+
+	// stdout, stderr and frames are only populated when the Streamed() option was used;
+	// Stdout, Stderr and Frames return nil/zero values otherwise.
+	stdout    *ringBuffer
+	stderr    *ringBuffer
+	frames    chan Frame
+	truncated int32
+
+	// signal, when set by the Runner that created this CommandExecutor, delivers sig to
+	// the running command. A CommandExecutor created without it reports an error from
+	// Signal.
+	signal func(sig os.Signal) error
 }
 
-// Read implements the io.Reader.
-// CommandExecutor will read from stdout and stderr
+// Signal delivers sig to the running command. It returns an error if the command has
+// already finished or if the Runner that started it doesn't support signaling.
+func (c *CommandExecutor) Signal(sig os.Signal) error {
+	if c.signal == nil {
+		return errors.New("exec: Signal is not supported by this runner")
+	}
+	return c.signal(sig)
+}
+
+// Read implements the io.Reader. CommandExecutor will read from stdout and stderr
+// merged together. In Streamed mode this is not populated - use Stdout, Stderr and
+// Frames instead.
 func (c *CommandExecutor) Read(p []byte) (int, error) {
 	return c.pipe.Read(p)
 }
 
-// Run spawns the given command and returns a handle to the running process in the form
-// of a CommandExecutor.
-func Run(ctx context.Context, command string, arg []string) (*CommandExecutor, error) {
-	if ctx == nil {
-		ctx = context.Background()
+// Stdout returns a reader over just the command's standard output. It is only non-nil
+// when the command was started with the Streamed() option.
+func (c *CommandExecutor) Stdout() io.Reader {
+	if c.stdout == nil {
+		return nil
 	}
+	return c.stdout
+}
 
-	// by default Cancel is spineless unless someone configures an option to enable it
-	commandExecutor := &CommandExecutor{Done: make(chan error, 1), done: make(chan error, 1)}
-
-	cmd := exec.CommandContext(ctx, command, arg...)
-	go func() {
-		var err error
-		defer func() { commandExecutor.Done <- err }()
-
-		select {
-		case <-ctx.Done():
-			err = ctx.Err()
-		case err = <-commandExecutor.done:
-		}
-	}()
-
-	// Create a new PIPE.
-	// stdout and stderr will be both redirected to this pipe. When the command is executed / cancelled or timeout
-	// reached the pipe will be closed, unblocking the reader.
-	r, w := io.Pipe()
-	cmd.Stdout = w
-	cmd.Stderr = w
-	commandExecutor.pipe = r
-
-	// execute the command in the goroutine.
-	go func() {
-		defer w.Close()
-		commandExecutor.done <- cmd.Run()
-	}()
-
-	return commandExecutor, nil
+// Stderr returns a reader over just the command's standard error. It is only non-nil
+// when the command was started with the Streamed() option.
+func (c *CommandExecutor) Stderr() io.Reader {
+	if c.stderr == nil {
+		return nil
+	}
+	return c.stderr
 }
 
-// Output returns stdout, stderr and error status for a given shell command
-func Output(ctx context.Context, timeout time.Duration, command ...string) ([]byte, []byte, error) {
+// Frames returns a channel of timestamped, stream-tagged output chunks, interleaved in
+// the order they were produced. It is only non-nil when the command was started with
+// the Streamed() option, and is closed once the command's output has been fully drained.
+func (c *CommandExecutor) Frames() <-chan Frame {
+	return c.frames
+}
+
+// Truncated reports whether either stream's ring buffer had to drop unread data because
+// a reader fell behind. Only meaningful in Streamed() mode.
+func (c *CommandExecutor) Truncated() bool {
+	return atomic.LoadInt32(&c.truncated) == 1
+}
+
+// Run spawns the given command and returns a handle to the running process in the form
+// of a CommandExecutor. By default the command runs on the local host; pass WithHost or
+// WithContainer to dispatch it to a remote node or a running container instead.
+func Run(ctx context.Context, command string, arg []string, opts ...RunOption) (*CommandExecutor, error) {
+	runner, err := runnerFor(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return runner.Run(ctx, Cmd{Path: command, Args: arg})
+}
 
+// Output returns stdout, stderr and error status for a given shell command, run on the
+// local host.
+func Output(ctx context.Context, timeout time.Duration, command ...string) ([]byte, []byte, error) {
 	var (
 		// define an empty cancel function
 		cancel context.CancelFunc = func() {}
@@ -111,40 +138,5 @@ func Output(ctx context.Context, timeout time.Duration, command ...string) ([]by
 		arg = command[1:]
 	}
 
-	cmd := exec.CommandContext(ctx, command[0], arg...)
-
-	// create stdout/stderr pipes for combined output.
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "unable to open stdout pipe")
-	}
-
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "unable to open stderr pipe")
-	}
-
-	// start command execution
-	if err := cmd.Start(); err != nil {
-		return nil, nil, errors.Wrapf(err, "unable to run command %s", command)
-	}
-
-	bufStdout := new(bytes.Buffer)
-	stdoutR := io.Reader(stdout)
-	if _, err := io.Copy(bufStdout, stdoutR); err != nil {
-		return nil, nil, errors.Wrap(err, "unable to copy")
-	}
-
-	bufStderr := new(bytes.Buffer)
-	stderrR := io.Reader(stderr)
-	if _, err := io.Copy(bufStderr, stderrR); err != nil {
-		return nil, nil, errors.Wrap(err, "unable to copy")
-	}
-
-	// do not wrap cmd.Wait() error, it is used to determine exit code
-	if err := cmd.Wait(); err != nil {
-		return bufStdout.Bytes(), bufStderr.Bytes(), errors.Wrapf(err, "unabl to wait for command %s", command)
-	}
-
-	return bufStdout.Bytes(), bufStderr.Bytes(), nil
+	return defaultRunner.Output(ctx, Cmd{Path: command[0], Args: arg})
 }
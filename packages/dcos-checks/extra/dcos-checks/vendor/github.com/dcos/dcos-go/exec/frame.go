@@ -0,0 +1,29 @@
+package exec
+
+import "time"
+
+// Stream identifies which of a command's output streams a Frame came from.
+type Stream int
+
+const (
+	// Stdout identifies a Frame read from the command's standard output.
+	Stdout Stream = iota
+	// Stderr identifies a Frame read from the command's standard error.
+	Stderr
+)
+
+func (s Stream) String() string {
+	if s == Stderr {
+		return "stderr"
+	}
+	return "stdout"
+}
+
+// Frame is one write a command made to stdout or stderr, tagged with which stream it
+// came from and when it was observed. Frames preserve stdout/stderr separation for
+// callers that still want the two interleaved in time order.
+type Frame struct {
+	Stream Stream
+	Data   []byte
+	Time   time.Time
+}
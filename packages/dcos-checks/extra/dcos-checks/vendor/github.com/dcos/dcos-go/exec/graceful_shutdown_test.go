@@ -0,0 +1,71 @@
+package exec
+
+import (
+	"context"
+	"io/ioutil"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestRunCmdGracefulShutdown verifies that a command which traps SIGTERM and exits on
+// its own is reported as ErrGracefulShutdown rather than ErrTimeout.
+func TestRunCmdGracefulShutdown(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	ce, err := RunCmd(ctx, Cmd{
+		Path: "bash",
+		Args: []string{"-c", "trap 'exit 0' TERM; sleep 30"},
+	}, WithGracefulShutdown(3*time.Second))
+	if err != nil {
+		t.Fatalf("RunCmd returned error: %s", err)
+	}
+
+	if _, err := ioutil.ReadAll(ce); err != nil {
+		t.Fatalf("unable to read command output: %s", err)
+	}
+
+	select {
+	case err := <-ce.Done:
+		if err != ErrGracefulShutdown {
+			t.Fatalf("expected ErrGracefulShutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Done channel did not fire")
+	}
+}
+
+// TestCommandExecutorSignal verifies Signal delivers an arbitrary signal to the running
+// process group, causing it to exit on its own.
+func TestCommandExecutorSignal(t *testing.T) {
+	ce, err := RunCmd(context.Background(), Cmd{
+		Path: "bash",
+		Args: []string{"-c", "trap 'exit 0' TERM; sleep 30"},
+	})
+	if err != nil {
+		t.Fatalf("RunCmd returned error: %s", err)
+	}
+
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		ioutil.ReadAll(ce)
+	}()
+
+	if err := ce.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("Signal returned error: %s", err)
+	}
+
+	select {
+	case <-readDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("command output did not finish after Signal")
+	}
+
+	select {
+	case <-ce.Done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Done channel did not fire after Signal")
+	}
+}
@@ -0,0 +1,364 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LocalRunner executes commands on the local host via os/exec. It is the Runner
+// implementation backing the package-level Run and Output functions.
+type LocalRunner struct{}
+
+// NewLocalRunner returns a Runner that executes commands on the local host.
+func NewLocalRunner() *LocalRunner {
+	return &LocalRunner{}
+}
+
+// defaultRunner is used by the package-level Run/Output wrappers when no RunOption
+// selects a remote host or container.
+var defaultRunner = NewLocalRunner()
+
+// Run spawns cmd locally and returns a handle to the running process.
+//
+// stdout and stderr are backed by real os.Pipe file descriptors rather than an
+// in-process io.Pipe. A child that forks grandchildren which inherit the write end of
+// the pipe (e.g. `sleep 30 &`) would otherwise keep that write end open forever, so
+// cmd.Wait() would never observe EOF and the reader would block past the context
+// deadline. With an os.Pipe we instead own the cleanup: we close our copy of the write
+// end as soon as the child has started, and when the context is done we kill the process
+// group, which unblocks both cmd.Wait() and the reader deterministically.
+//
+// c.Stdout, c.Stderr, c.StdoutPath and c.StderrPath, when set, additionally receive a
+// copy of each stream; outside Streamed mode the CommandExecutor's own Read returns the
+// two merged regardless of those settings.
+func (r *LocalRunner) Run(ctx context.Context, c Cmd) (*CommandExecutor, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	commandExecutor := &CommandExecutor{Done: make(chan error, 1), done: make(chan error, 1)}
+
+	cmd := exec.Command(c.Path, c.Args...)
+	cmd.Dir = c.Dir
+	if c.Env != nil {
+		cmd.Env = c.Env
+	}
+	cmd.Stdin = c.Stdin
+
+	// Run the process in its own group so that on cancellation we can signal every
+	// descendant it spawned, not just the direct child.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create stdout pipe")
+	}
+	stderrR, stderrW, err := os.Pipe()
+	if err != nil {
+		stdoutR.Close()
+		stdoutW.Close()
+		return nil, errors.Wrap(err, "unable to create stderr pipe")
+	}
+
+	// cmd.Stdout/cmd.Stderr must be the *os.File ends of our os.Pipe directly: os/exec
+	// only hands the child our fd as-is when it's an *os.File. Any other io.Writer
+	// (e.g. an io.MultiWriter) makes it create its own internal pipe and relay goroutine
+	// instead, which breaks once we close our stdoutW/stderrW below. The ring
+	// buffer/tee-file/caller-writer fan-out therefore happens on the read side, in the
+	// copy goroutines below, not here.
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stderrW
+
+	var stdoutSinks, stderrSinks []io.Writer
+
+	if c.streamed {
+		commandExecutor.stdout = newRingBuffer(c.streamBufferSize, &commandExecutor.truncated)
+		commandExecutor.stderr = newRingBuffer(c.streamBufferSize, &commandExecutor.truncated)
+		commandExecutor.frames = make(chan Frame, streamFramesBufferSize)
+		stdoutSinks = append(stdoutSinks, &frameWriter{stream: Stdout, ring: commandExecutor.stdout, frames: commandExecutor.frames})
+		stderrSinks = append(stderrSinks, &frameWriter{stream: Stderr, ring: commandExecutor.stderr, frames: commandExecutor.frames})
+	}
+
+	// rawStdoutSinks holds only the caller-provided stdout destinations (Stdout and the
+	// StdoutPath tee file), not the Streamed-mode frameWriter appended above. MergeStderr
+	// redirects into these alone, so that merging stderr into stdout doesn't also feed
+	// stderr bytes into the stdout ring buffer/Frames and break Streamed's per-stream
+	// separation.
+	var rawStdoutSinks []io.Writer
+	if c.Stdout != nil {
+		rawStdoutSinks = append(rawStdoutSinks, c.Stdout)
+	}
+	if c.StdoutPath != "" {
+		f, ferr := createTeeFile(c.StdoutPath)
+		if ferr != nil {
+			stdoutR.Close()
+			stdoutW.Close()
+			stderrR.Close()
+			stderrW.Close()
+			return nil, ferr
+		}
+		rawStdoutSinks = append(rawStdoutSinks, f)
+	}
+	stdoutSinks = append(stdoutSinks, rawStdoutSinks...)
+
+	// MergeStderr(true) routes stderr into the raw stdout sinks instead of its own, for
+	// callers that only configured Stdout/StdoutPath and want everything there.
+	if c.mergeStderrSet && c.mergeStderr {
+		stderrSinks = append(stderrSinks, rawStdoutSinks...)
+	} else {
+		if c.Stderr != nil {
+			stderrSinks = append(stderrSinks, c.Stderr)
+		}
+		if c.StderrPath != "" {
+			f, ferr := createTeeFile(c.StderrPath)
+			if ferr != nil {
+				stdoutR.Close()
+				stdoutW.Close()
+				stderrR.Close()
+				stderrW.Close()
+				return nil, ferr
+			}
+			stderrSinks = append(stderrSinks, f)
+		}
+	}
+
+	pipeR, pipeW := io.Pipe()
+	commandExecutor.pipe = pipeR
+
+	// In Streamed mode, callers are expected to read Stdout()/Stderr()/Frames() rather
+	// than the merged Read(); feeding pipeW as well would mean a caller that never reads
+	// the merged stream (the common case in Streamed mode) blocks the copy goroutines
+	// below forever, since io.MultiWriter's writes are sequential and an unread io.Pipe
+	// never stops blocking.
+	var mergedSinks []io.Writer
+	if !c.streamed {
+		mergedSinks = []io.Writer{pipeW}
+	}
+
+	if err := cmd.Start(); err != nil {
+		stdoutR.Close()
+		stdoutW.Close()
+		stderrR.Close()
+		stderrW.Close()
+		return nil, errors.Wrapf(err, "unable to start command %s", c.Path)
+	}
+
+	pgid := cmd.Process.Pid
+	commandExecutor.signal = func(sig os.Signal) error {
+		s, ok := sig.(syscall.Signal)
+		if !ok {
+			return errors.Errorf("unsupported signal type %T", sig)
+		}
+		return syscall.Kill(-pgid, s)
+	}
+
+	// The child now holds its own copy of each write end; the parent's copies must be
+	// closed so the readers see EOF once every holder of a write end has exited,
+	// instead of waiting on cmd.Wait() which can block forever on a lingering
+	// grandchild.
+	stdoutW.Close()
+	stderrW.Close()
+
+	// copyDone signals that both os.Pipe -> io.Pipe copies have finished. Each stream is
+	// read once from its os.Pipe and fanned out to the merged pipeW plus whatever
+	// ring buffer/tee file/caller writer was configured for it, via io.MultiWriter.
+	copyDone := make(chan struct{})
+	go func() {
+		defer close(copyDone)
+		defer pipeW.Close()
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			defer stdoutR.Close()
+			io.Copy(io.MultiWriter(append(mergedSinks, stdoutSinks...)...), stdoutR)
+		}()
+		go func() {
+			defer wg.Done()
+			defer stderrR.Close()
+			io.Copy(io.MultiWriter(append(mergedSinks, stderrSinks...)...), stderrR)
+		}()
+		wg.Wait()
+	}()
+
+	go func() {
+		commandExecutor.done <- cmd.Wait()
+	}()
+
+	go func() {
+		defer func() { commandExecutor.Done <- err }()
+
+		select {
+		case <-ctx.Done():
+			if stopProcessGroup(cmd, c.killGracePeriod, commandExecutor.done) {
+				err = ErrGracefulShutdown
+			} else if ctx.Err() == context.DeadlineExceeded {
+				err = ErrTimeout
+			} else {
+				err = ErrCanceled
+			}
+			<-copyDone
+		case err = <-commandExecutor.done:
+			// The direct child can exit almost immediately while an orphaned
+			// grandchild it spawned (e.g. `sleep 30 &`) keeps holding the pipe's
+			// write end open, in which case copyDone never fires on its own. Keep
+			// watching ctx here so a timeout/cancellation still reaches the orphan
+			// instead of hanging until it exits naturally.
+			select {
+			case <-copyDone:
+			case <-ctx.Done():
+				killProcessGroup(cmd)
+				<-copyDone
+				if ctx.Err() == context.DeadlineExceeded {
+					err = ErrTimeout
+				} else {
+					err = ErrCanceled
+				}
+			}
+		}
+
+		if c.streamed {
+			commandExecutor.stdout.Close()
+			commandExecutor.stderr.Close()
+			close(commandExecutor.frames)
+		}
+	}()
+
+	return commandExecutor, nil
+}
+
+// streamFramesBufferSize bounds the Frames() channel. Unlike the ring buffers backing
+// Stdout()/Stderr(), a full Frames() channel simply drops the frame rather than
+// blocking the command - the ring buffers remain the source of truth for output.
+const streamFramesBufferSize = 256
+
+// frameWriter tees writes into a ring buffer and, best-effort, onto a Frames() channel
+// tagged with the stream they came from.
+type frameWriter struct {
+	stream Stream
+	ring   *ringBuffer
+	frames chan Frame
+}
+
+func (f *frameWriter) Write(p []byte) (int, error) {
+	n, err := f.ring.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	data := make([]byte, len(p))
+	copy(data, p)
+	select {
+	case f.frames <- Frame{Stream: f.stream, Data: data, Time: time.Now()}:
+	default:
+	}
+
+	return n, nil
+}
+
+// createTeeFile opens path for writing, creating parent directories and truncating any
+// existing content, so long-running diagnostics can tee a live stream to an on-disk log.
+func createTeeFile(path string) (*os.File, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, errors.Wrapf(err, "unable to create directory %s", dir)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open %s", path)
+	}
+	return f, nil
+}
+
+// stopProcessGroup terminates the process group rooted at cmd's PID. With a positive
+// gracePeriod it sends SIGTERM first and only escalates to SIGKILL if the group hasn't
+// exited (signaled on done) within gracePeriod; otherwise it kills immediately. It
+// reports whether the group exited on its own after SIGTERM, i.e. whether this was a
+// graceful shutdown rather than a hard kill.
+func stopProcessGroup(cmd *exec.Cmd, gracePeriod time.Duration, done <-chan error) bool {
+	if cmd.Process == nil {
+		return false
+	}
+	pgid := -cmd.Process.Pid
+
+	if gracePeriod <= 0 {
+		syscall.Kill(pgid, syscall.SIGKILL)
+		return false
+	}
+
+	syscall.Kill(pgid, syscall.SIGTERM)
+	select {
+	case <-done:
+		return true
+	case <-time.After(gracePeriod):
+		syscall.Kill(pgid, syscall.SIGKILL)
+		return false
+	}
+}
+
+// killProcessGroup immediately SIGKILLs the process group rooted at cmd's PID, with no
+// grace period. It's used once the command itself has already exited and all that's
+// left to clean up is an orphaned descendant still holding a pipe open.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// Output runs cmd locally to completion and returns its buffered stdout and stderr.
+func (r *LocalRunner) Output(ctx context.Context, c Cmd) ([]byte, []byte, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cmd := exec.CommandContext(ctx, c.Path, c.Args...)
+	cmd.Dir = c.Dir
+	if c.Env != nil {
+		cmd.Env = c.Env
+	}
+	cmd.Stdin = c.Stdin
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to open stdout pipe")
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to open stderr pipe")
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, errors.Wrapf(err, "unable to run command %s", c.Path)
+	}
+
+	bufStdout := new(bytes.Buffer)
+	if _, err := io.Copy(bufStdout, stdout); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to copy")
+	}
+
+	bufStderr := new(bytes.Buffer)
+	if _, err := io.Copy(bufStderr, stderr); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to copy")
+	}
+
+	// cmd.Wait()'s error is wrapped; errors.Cause returns the underlying error, which
+	// carries the exit code.
+	if err := cmd.Wait(); err != nil {
+		return bufStdout.Bytes(), bufStderr.Bytes(), errors.Wrapf(err, "unabl to wait for command %s", c.Path)
+	}
+
+	return bufStdout.Bytes(), bufStderr.Bytes(), nil
+}
@@ -0,0 +1,39 @@
+package exec
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// TestRunUnblocksOnOrphanedGrandchild verifies that a command which spawns a
+// detached grandchild holding the stdout/stderr fd open does not wedge Wait()
+// past the context timeout.
+func TestRunUnblocksOnOrphanedGrandchild(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ce, err := Run(ctx, "bash", []string{"-c", "sleep 30 & echo done"})
+	if err != nil {
+		t.Fatalf("Run returned error: %s", err)
+	}
+
+	start := time.Now()
+	if _, err := ioutil.ReadAll(ce); err != nil {
+		t.Fatalf("unable to read command output: %s", err)
+	}
+
+	select {
+	case err := <-ce.Done:
+		if err != ErrTimeout {
+			t.Fatalf("expected ErrTimeout, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Done channel did not fire shortly after the context deadline")
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Read blocked for %s, expected it to unblock close to the 1s timeout", elapsed)
+	}
+}
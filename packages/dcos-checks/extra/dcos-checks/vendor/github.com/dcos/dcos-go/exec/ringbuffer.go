@@ -0,0 +1,122 @@
+package exec
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// ringBuffer is a fixed-capacity byte buffer safe for one writer and one reader. Unlike
+// io.Pipe, Write never blocks: once the buffer is full it overwrites the oldest
+// unread bytes and reports the overwrite through truncated, so a slow reader can never
+// stall the process producing the data. Read blocks until bytes are available or the
+// buffer is closed.
+type ringBuffer struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	buf       []byte
+	start     int // index of the oldest unread byte
+	size      int // number of unread bytes currently buffered
+	closed    bool
+	truncated *int32
+}
+
+func newRingBuffer(capacity int, truncated *int32) *ringBuffer {
+	if capacity <= 0 {
+		capacity = defaultStreamBufferSize
+	}
+	rb := &ringBuffer{buf: make([]byte, capacity), truncated: truncated}
+	rb.cond = sync.NewCond(&rb.mu)
+	return rb
+}
+
+// defaultStreamBufferSize bounds each stream's ring buffer when Streamed() is used
+// without an explicit size.
+const defaultStreamBufferSize = 1 << 20 // 1MiB
+
+// Write appends p to the buffer, dropping the oldest bytes first if p doesn't fit.
+func (rb *ringBuffer) Write(p []byte) (int, error) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	defer rb.cond.Broadcast()
+
+	n := len(p)
+	bufCap := len(rb.buf)
+
+	if n >= bufCap {
+		// p alone overflows the buffer; keep only its tail.
+		if (rb.size > 0 || n > bufCap) && rb.truncated != nil {
+			atomic.StoreInt32(rb.truncated, 1)
+		}
+		copy(rb.buf, p[n-bufCap:])
+		rb.start = 0
+		rb.size = bufCap
+		return n, nil
+	}
+
+	free := bufCap - rb.size
+	if n > free {
+		// Drop the oldest (n - free) bytes to make room.
+		drop := n - free
+		rb.start = (rb.start + drop) % bufCap
+		rb.size -= drop
+		if rb.truncated != nil {
+			atomic.StoreInt32(rb.truncated, 1)
+		}
+	}
+
+	writeAt := (rb.start + rb.size) % bufCap
+	tail := bufCap - writeAt
+	if tail >= n {
+		copy(rb.buf[writeAt:], p)
+	} else {
+		copy(rb.buf[writeAt:], p[:tail])
+		copy(rb.buf, p[tail:])
+	}
+	rb.size += n
+
+	return n, nil
+}
+
+// Read blocks until at least one byte is available or the buffer is closed and drained,
+// in which case it returns io.EOF.
+func (rb *ringBuffer) Read(p []byte) (int, error) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	for rb.size == 0 && !rb.closed {
+		rb.cond.Wait()
+	}
+	if rb.size == 0 && rb.closed {
+		return 0, io.EOF
+	}
+
+	bufCap := len(rb.buf)
+	n := len(p)
+	if n > rb.size {
+		n = rb.size
+	}
+
+	tail := bufCap - rb.start
+	if tail >= n {
+		copy(p, rb.buf[rb.start:rb.start+n])
+	} else {
+		copy(p, rb.buf[rb.start:])
+		copy(p[tail:], rb.buf[:n-tail])
+	}
+
+	rb.start = (rb.start + n) % bufCap
+	rb.size -= n
+
+	return n, nil
+}
+
+// Close marks the buffer closed, unblocking any pending Read once it has drained the
+// remaining bytes.
+func (rb *ringBuffer) Close() error {
+	rb.mu.Lock()
+	rb.closed = true
+	rb.mu.Unlock()
+	rb.cond.Broadcast()
+	return nil
+}
@@ -0,0 +1,66 @@
+package exec
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Runner executes a Cmd somewhere - on the local host, on a remote node over SSH, or
+// inside a running container - and reports its output through the same CommandExecutor
+// abstraction regardless of where it actually ran. This lets callers such as
+// ClusterVersionCheck fan a command out over every master and agent without caring how
+// each node is reached.
+type Runner interface {
+	// Run starts command and returns a handle the caller can read output from and wait
+	// on for completion, same as the package-level Run.
+	Run(ctx context.Context, cmd Cmd) (*CommandExecutor, error)
+
+	// Output runs command to completion and returns its buffered stdout and stderr, same
+	// as the package-level Output.
+	Output(ctx context.Context, cmd Cmd) (stdout, stderr []byte, err error)
+}
+
+// runnerConfig is built up by RunOption and consulted by runnerFor to select which
+// Runner backs a given Run/Output call.
+type runnerConfig struct {
+	host      string
+	container string
+}
+
+// RunOption configures which Runner a Run/Output call is dispatched to.
+type RunOption func(*runnerConfig)
+
+// WithHost directs the command to run on the named node over SSH instead of locally.
+func WithHost(host string) RunOption {
+	return func(c *runnerConfig) {
+		c.host = host
+	}
+}
+
+// WithContainer directs the command to run inside the named container instead of locally.
+func WithContainer(id string) RunOption {
+	return func(c *runnerConfig) {
+		c.container = id
+	}
+}
+
+// runnerFor resolves a runnerConfig built from opts into the Runner that should execute
+// the command. It returns an error if the options conflict with each other.
+func runnerFor(opts ...RunOption) (Runner, error) {
+	var cfg runnerConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	switch {
+	case cfg.host != "" && cfg.container != "":
+		return nil, errors.New("WithHost and WithContainer cannot both be set")
+	case cfg.host != "":
+		return NewSSHRunner(cfg.host), nil
+	case cfg.container != "":
+		return NewContainerRunner(cfg.container), nil
+	default:
+		return defaultRunner, nil
+	}
+}
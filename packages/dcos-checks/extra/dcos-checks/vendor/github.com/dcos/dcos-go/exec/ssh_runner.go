@@ -0,0 +1,232 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// defaultSSHPort is used when a host is given without an explicit port.
+const defaultSSHPort = "22"
+
+// SSHRunner executes commands on a remote node over SSH. It opens one session per
+// command, which keeps it simple at the cost of a fresh TCP+SSH handshake per call;
+// callers fanning out over many nodes are expected to create one SSHRunner per node and
+// reuse it across checks.
+type SSHRunner struct {
+	host   string
+	config *ssh.ClientConfig
+}
+
+// NewSSHRunner returns a Runner that dials host (host or host:port, defaulting to port
+// 22) and executes commands there. Authentication is taken from the SSH agent reachable
+// through the SSH_AUTH_SOCK environment variable, matching how the DC/OS CLI reaches
+// cluster nodes today.
+func NewSSHRunner(host string) *SSHRunner {
+	return &SSHRunner{
+		host:   host,
+		config: sshConfigFromAgent(),
+	}
+}
+
+func sshConfigFromAgent() *ssh.ClientConfig {
+	var authMethods []ssh.AuthMethod
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			authMethods = append(authMethods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+
+	return &ssh.ClientConfig{
+		User:            "root",
+		Auth:            authMethods,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         10 * time.Second,
+	}
+}
+
+func (r *SSHRunner) dial() (*ssh.Client, error) {
+	addr := r.host
+	if !strings.Contains(addr, ":") {
+		addr = net.JoinHostPort(addr, defaultSSHPort)
+	}
+	client, err := ssh.Dial("tcp", addr, r.config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to dial %s", addr)
+	}
+	return client, nil
+}
+
+// Run opens a new SSH session on r.host, starts cmd on it, and streams its combined
+// stdout/stderr through a CommandExecutor, same as the local Runner.
+func (r *SSHRunner) Run(ctx context.Context, cmd Cmd) (*CommandExecutor, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	client, err := r.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		client.Close()
+		return nil, errors.Wrapf(err, "unable to open SSH session on %s", r.host)
+	}
+
+	pipeR, pipeW := io.Pipe()
+	session.Stdout = pipeW
+	session.Stderr = pipeW
+
+	commandExecutor := &CommandExecutor{Done: make(chan error, 1), done: make(chan error, 1)}
+	commandExecutor.pipe = pipeR
+	commandExecutor.signal = func(sig os.Signal) error {
+		s, err := sshSignal(sig)
+		if err != nil {
+			return err
+		}
+		return session.Signal(s)
+	}
+
+	if err := session.Start(cmd.commandLine()); err != nil {
+		session.Close()
+		client.Close()
+		return nil, errors.Wrapf(err, "unable to start command %s on %s", cmd.Path, r.host)
+	}
+
+	go func() {
+		commandExecutor.done <- session.Wait()
+	}()
+
+	go func() {
+		var err error
+		defer session.Close()
+		defer client.Close()
+		defer pipeW.Close()
+		defer func() { commandExecutor.Done <- err }()
+
+		select {
+		case <-ctx.Done():
+			if stopSSHSession(session, cmd.killGracePeriod, commandExecutor.done) {
+				err = ErrGracefulShutdown
+			} else if ctx.Err() == context.DeadlineExceeded {
+				err = ErrTimeout
+			} else {
+				err = ErrCanceled
+			}
+		case err = <-commandExecutor.done:
+		}
+	}()
+
+	return commandExecutor, nil
+}
+
+// stopSSHSession sends SIGTERM and, with a positive gracePeriod, waits for the session
+// to exit on its own before escalating to SIGKILL. It reports whether the session exited
+// on its own, i.e. whether this was a graceful shutdown.
+func stopSSHSession(session *ssh.Session, gracePeriod time.Duration, done <-chan error) bool {
+	if gracePeriod <= 0 {
+		session.Signal(ssh.SIGKILL)
+		return false
+	}
+
+	session.Signal(ssh.SIGTERM)
+	select {
+	case <-done:
+		return true
+	case <-time.After(gracePeriod):
+		session.Signal(ssh.SIGKILL)
+		return false
+	}
+}
+
+// sshSignal maps a Go os.Signal to the ssh.Signal golang.org/x/crypto/ssh expects.
+func sshSignal(sig os.Signal) (ssh.Signal, error) {
+	switch sig {
+	case syscall.SIGTERM:
+		return ssh.SIGTERM, nil
+	case syscall.SIGKILL:
+		return ssh.SIGKILL, nil
+	case syscall.SIGINT:
+		return ssh.SIGINT, nil
+	case syscall.SIGHUP:
+		return ssh.SIGHUP, nil
+	case syscall.SIGQUIT:
+		return ssh.SIGQUIT, nil
+	default:
+		return "", errors.Errorf("unsupported signal %v over SSH", sig)
+	}
+}
+
+// Output runs cmd on r.host over SSH to completion and returns its buffered stdout and
+// stderr.
+func (r *SSHRunner) Output(ctx context.Context, cmd Cmd) ([]byte, []byte, error) {
+	client, err := r.dial()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "unable to open SSH session on %s", r.host)
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	done := make(chan error, 1)
+	go func() { done <- session.Run(cmd.commandLine()) }()
+
+	select {
+	case <-ctx.Done():
+		session.Signal(ssh.SIGKILL)
+		return stdout.Bytes(), stderr.Bytes(), ctx.Err()
+	case err := <-done:
+		return stdout.Bytes(), stderr.Bytes(), err
+	}
+}
+
+// commandLine joins Path and Args into the single string ssh.Session.Start/Run expects,
+// since an SSH session execs the remote user's shell rather than the binary directly.
+// Every part is shell-quoted so that args containing spaces or shell metacharacters
+// reach the remote command as a single, literal argument instead of being split or
+// interpreted by the remote shell - unlike os/exec, which never passes Args through a
+// shell at all. Dir and Env, which os/exec applies directly to the child process, have
+// no equivalent on an SSH session, so they're approximated with a `cd`/`export` prefix
+// on the same command line.
+func (c Cmd) commandLine() string {
+	var parts []string
+
+	if c.Dir != "" {
+		parts = append(parts, "cd", shellQuote(c.Dir), "&&")
+	}
+	for _, kv := range c.Env {
+		parts = append(parts, "export", shellQuote(kv)+";")
+	}
+
+	parts = append(parts, shellQuote(c.Path))
+	for _, arg := range c.Args {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// shellQuote single-quotes s for safe inclusion in a POSIX shell command line,
+// escaping any embedded single quotes, so a remote shell always sees it as one literal
+// word regardless of spaces or metacharacters it contains.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
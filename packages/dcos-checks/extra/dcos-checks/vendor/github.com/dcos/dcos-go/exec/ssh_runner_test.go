@@ -0,0 +1,50 @@
+package exec
+
+import "testing"
+
+func TestCommandLineQuotesArgs(t *testing.T) {
+	cases := []struct {
+		name string
+		cmd  Cmd
+		want string
+	}{
+		{
+			name: "simple",
+			cmd:  Cmd{Path: "echo", Args: []string{"hello"}},
+			want: "'echo' 'hello'",
+		},
+		{
+			name: "arg with space is not split into two words",
+			cmd:  Cmd{Path: "echo", Args: []string{"hello world"}},
+			want: "'echo' 'hello world'",
+		},
+		{
+			name: "shell metacharacters are not interpreted",
+			cmd:  Cmd{Path: "echo", Args: []string{"hello world; rm -rf /tmp/evil"}},
+			want: "'echo' 'hello world; rm -rf /tmp/evil'",
+		},
+		{
+			name: "embedded single quote is escaped",
+			cmd:  Cmd{Path: "echo", Args: []string{"it's"}},
+			want: `'echo' 'it'\''s'`,
+		},
+		{
+			name: "Dir is approximated with a cd prefix",
+			cmd:  Cmd{Path: "echo", Args: []string{"hi"}, Dir: "/tmp/work dir"},
+			want: "cd '/tmp/work dir' && 'echo' 'hi'",
+		},
+		{
+			name: "Env is approximated with an export prefix",
+			cmd:  Cmd{Path: "echo", Args: []string{"hi"}, Env: []string{"FOO=bar baz"}},
+			want: "export 'FOO=bar baz'; 'echo' 'hi'",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.cmd.commandLine(); got != c.want {
+				t.Fatalf("commandLine() = %q, want %q", got, c.want)
+			}
+		})
+	}
+}